@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const githubAPIBase = "https://api.github.com"
+
+// rateLimitLowWatermark is the X-RateLimit-Remaining threshold below which
+// we stop trusting the configured WatchInterval and instead wait until the
+// window resets, so conditional polling never eats into the primary quota.
+const rateLimitLowWatermark = 10
+
+// watchForChangesViaAPI is the --poll-mode=api counterpart to
+// watchForChanges: it checks GitHub's commits API with conditional
+// (ETag) requests instead of running a local git fetch on every tick, and
+// only falls through to go-git once a repo's SHA has actually moved.
+func watchForChangesViaAPI(ctx context.Context, config Config, queue *workQueue) {
+	store, err := loadStateStore(config.StateFilePath)
+	if err != nil {
+		slog.Error("Failed to load poll state, starting fresh", "path", config.StateFilePath, "error", err)
+		store = &stateStore{path: config.StateFilePath, states: make(map[string]repoAPIState)}
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	interval := time.Duration(config.WatchInterval) * time.Second
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping API watch loop")
+			return
+		case <-timer.C:
+			nextInterval := interval
+
+			for _, repo := range config.WatchRepos {
+				pollStart := time.Now()
+				changed, waitFor, err := pollRepoViaAPI(ctx, client, config, repo, store)
+				metrics.GitPollDuration.WithLabelValues(repo).Observe(time.Since(pollStart).Seconds())
+
+				if err != nil {
+					metrics.GitPollErrors.WithLabelValues(repo).Inc()
+					slog.Error("Error polling repository via API", "repo", repo, "error", err)
+					continue
+				}
+
+				if waitFor > nextInterval {
+					nextInterval = waitFor
+				}
+
+				if changed {
+					slog.Info("Detected changes via API", "repo", repo)
+					queue.enqueue(repo)
+				}
+			}
+
+			timer.Reset(nextInterval)
+		}
+	}
+}
+
+// pollRepoViaAPI issues a conditional GET against the commits API for
+// repo/branch, updates the on-disk state, and reports whether the repo's
+// SHA changed and how long to wait before the next poll (extended when the
+// rate-limit budget is running low).
+func pollRepoViaAPI(ctx context.Context, client *http.Client, config Config, repo string, store *stateStore) (changed bool, waitFor time.Duration, err error) {
+	fullName := repoFullName(repo)
+	state := store.get(repo)
+
+	url := fmt.Sprintf("%s/repos/%s/commits/%s", githubAPIBase, fullName, config.Branch)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("building request for %s: %w", fullName, err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if config.GitHubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+config.GitHubToken)
+	}
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, 0, fmt.Errorf("requesting %s: %w", fullName, err)
+	}
+	defer resp.Body.Close()
+
+	waitFor = rateLimitWait(resp.Header)
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		state.LastCheck = time.Now()
+		if err := store.set(repo, state); err != nil {
+			slog.Warn("Failed to persist poll state", "repo", repo, "error", err)
+		}
+		return false, waitFor, nil
+
+	case http.StatusOK:
+		var payload struct {
+			SHA string `json:"sha"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return false, waitFor, fmt.Errorf("decoding response for %s: %w", fullName, err)
+		}
+
+		changed = state.LastSHA != "" && payload.SHA != state.LastSHA
+		oldSHA := state.LastSHA
+
+		state.ETag = resp.Header.Get("ETag")
+		state.LastSHA = payload.SHA
+		state.LastCheck = time.Now()
+		if err := store.set(repo, state); err != nil {
+			slog.Warn("Failed to persist poll state", "repo", repo, "error", err)
+		}
+
+		if changed {
+			recordRepoHash(repo, oldSHA, payload.SHA)
+		}
+
+		return changed, waitFor, nil
+
+	default:
+		return false, waitFor, fmt.Errorf("unexpected status %d polling %s", resp.StatusCode, fullName)
+	}
+}
+
+// rateLimitWait inspects GitHub's rate limit headers and, when the
+// remaining budget is low, returns how long to wait until the window
+// resets. It returns 0 when there's no reason to extend the poll interval.
+func rateLimitWait(header http.Header) time.Duration {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining > rateLimitLowWatermark {
+		return 0
+	}
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	wait := time.Until(time.Unix(reset, 0))
+	if wait < 0 {
+		return 0
+	}
+
+	slog.Warn("GitHub rate limit low, extending poll interval", "remaining", remaining, "wait", wait)
+	return wait
+}
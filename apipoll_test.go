@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	t.Run("plenty of budget remaining", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("X-RateLimit-Remaining", "500")
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+
+		if got := rateLimitWait(header); got != 0 {
+			t.Errorf("rateLimitWait() = %v, want 0", got)
+		}
+	})
+
+	t.Run("low budget waits until reset", func(t *testing.T) {
+		reset := time.Now().Add(5 * time.Minute)
+		header := http.Header{}
+		header.Set("X-RateLimit-Remaining", "3")
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		got := rateLimitWait(header)
+		if got <= 0 || got > 5*time.Minute+time.Second {
+			t.Errorf("rateLimitWait() = %v, want roughly 5m", got)
+		}
+	})
+
+	t.Run("reset already in the past", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("X-RateLimit-Remaining", "1")
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+
+		if got := rateLimitWait(header); got != 0 {
+			t.Errorf("rateLimitWait() = %v, want 0 for a past reset", got)
+		}
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		if got := rateLimitWait(http.Header{}); got != 0 {
+			t.Errorf("rateLimitWait() = %v, want 0 when headers are absent", got)
+		}
+	})
+}
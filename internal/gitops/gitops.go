@@ -0,0 +1,180 @@
+// Package gitops wraps the go-git operations this service needs to track
+// watched repositories, so credentials are passed per-operation instead of
+// being written into a shared ~/.gitconfig via git's insteadOf rewrite.
+package gitops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// Auth selects how to authenticate against the remote. SSHKeyPath takes
+// precedence over Username/Password when both are set; leaving everything
+// empty means the remote must be reachable without credentials.
+type Auth struct {
+	Username   string
+	Password   string
+	SSHKeyPath string
+}
+
+func (a Auth) method() (transport.AuthMethod, error) {
+	if a.SSHKeyPath != "" {
+		keys, err := gitssh.NewPublicKeysFromFile("git", a.SSHKeyPath, "")
+		if err != nil {
+			return nil, fmt.Errorf("loading SSH key %s: %w", a.SSHKeyPath, err)
+		}
+		return keys, nil
+	}
+	if a.Password != "" {
+		return &githttp.BasicAuth{Username: a.Username, Password: a.Password}, nil
+	}
+	return nil, nil
+}
+
+// Fetch fetches branch from origin into repoPath's local clone and returns
+// the resolved commit hash of refs/remotes/origin/<branch>. It never
+// touches the working tree, so it's cheap and safe to call on every poll
+// tick purely to detect whether anything changed.
+func Fetch(ctx context.Context, repoPath, branch string, auth Auth) (plumbing.Hash, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("opening repo %s: %w", repoPath, err)
+	}
+
+	authMethod, err := auth.method()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		Auth:       authMethod,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)),
+		},
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return plumbing.ZeroHash, fmt.Errorf("fetching %s: %w", repoPath, err)
+	}
+
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolving origin/%s for %s: %w", branch, repoPath, err)
+	}
+
+	return ref.Hash(), nil
+}
+
+// HeadHash returns repoPath's current HEAD commit, purely from the local
+// clone (no network access). Callers use this to capture the "before"
+// state ahead of a Pull so they can later diff what changed.
+func HeadHash(repoPath string) (plumbing.Hash, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("opening repo %s: %w", repoPath, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolving HEAD for %s: %w", repoPath, err)
+	}
+
+	return head.Hash(), nil
+}
+
+// ChangedFiles returns every file path touched between oldHash and newHash
+// (both sides of a rename are included), computed from the commits' patch
+// rather than shelling out to `git diff`. It returns an empty slice when
+// either hash is missing or they're equal.
+func ChangedFiles(repoPath string, oldHash, newHash plumbing.Hash) ([]string, error) {
+	if oldHash.IsZero() || newHash.IsZero() || oldHash == newHash {
+		return nil, nil
+	}
+
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening repo %s: %w", repoPath, err)
+	}
+
+	oldCommit, err := repo.CommitObject(oldHash)
+	if err != nil {
+		return nil, fmt.Errorf("resolving commit %s in %s: %w", oldHash, repoPath, err)
+	}
+
+	newCommit, err := repo.CommitObject(newHash)
+	if err != nil {
+		return nil, fmt.Errorf("resolving commit %s in %s: %w", newHash, repoPath, err)
+	}
+
+	patch, err := oldCommit.Patch(newCommit)
+	if err != nil {
+		return nil, fmt.Errorf("diffing %s..%s in %s: %w", oldHash, newHash, repoPath, err)
+	}
+
+	seen := make(map[string]struct{})
+	for _, fp := range patch.FilePatches() {
+		from, to := fp.Files()
+		if from != nil {
+			seen[from.Path()] = struct{}{}
+		}
+		if to != nil {
+			seen[to.Path()] = struct{}{}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// Pull fetches branch and fast-forwards the local branch ref and working
+// tree to match origin/<branch>, returning the resulting commit hash. Call
+// this once a change has actually been detected, since unlike Fetch it
+// touches the checkout.
+func Pull(ctx context.Context, repoPath, branch string, auth Auth) (plumbing.Hash, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("opening repo %s: %w", repoPath, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("getting worktree for %s: %w", repoPath, err)
+	}
+
+	authMethod, err := auth.method()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: branchRef}); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("checking out branch %s in %s: %w", branch, repoPath, err)
+	}
+
+	err = worktree.PullContext(ctx, &git.PullOptions{
+		RemoteName:    "origin",
+		ReferenceName: branchRef,
+		Auth:          authMethod,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return plumbing.ZeroHash, fmt.Errorf("pulling %s: %w", repoPath, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolving HEAD for %s: %w", repoPath, err)
+	}
+
+	return head.Hash(), nil
+}
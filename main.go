@@ -4,14 +4,19 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/vinnie-kaboom/test-action/internal/gitops"
 )
 
 type Config struct {
@@ -22,6 +27,13 @@ type Config struct {
 	Branch        string
 	GitHubToken   string
 	GitHubUser    string
+	WebhookListen string
+	WebhookSecret string
+	SSHKeyPath    string
+	PollMode      string
+	StateFilePath string
+	ShutdownGrace time.Duration
+	ConfigPath    string
 }
 
 func (c *Config) Validate() error {
@@ -46,6 +58,9 @@ func (c *Config) Validate() error {
 	if len(c.GitHubToken) < 40 {
 		return fmt.Errorf("invalid GitHub token format")
 	}
+	if c.PollMode != "git" && c.PollMode != "api" {
+		return fmt.Errorf("poll mode must be \"git\" or \"api\", got %q", c.PollMode)
+	}
 	return nil
 }
 
@@ -73,13 +88,38 @@ func (c *Config) LoadFromEnv() {
 	if user := os.Getenv("GITHUB_USER"); user != "" {
 		c.GitHubUser = user
 	}
+	if listen := os.Getenv("WEBHOOK_LISTEN"); listen != "" {
+		c.WebhookListen = listen
+	}
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		c.WebhookSecret = secret
+	}
+	if keyPath := os.Getenv("SSH_KEY_PATH"); keyPath != "" {
+		c.SSHKeyPath = keyPath
+	}
+	if mode := os.Getenv("POLL_MODE"); mode != "" {
+		c.PollMode = mode
+	}
+	if stateFile := os.Getenv("STATE_FILE"); stateFile != "" {
+		c.StateFilePath = stateFile
+	}
+	if grace := os.Getenv("SHUTDOWN_GRACE"); grace != "" {
+		if val, err := time.ParseDuration(grace); err == nil {
+			c.ShutdownGrace = val
+		}
+	}
+	if configPath := os.Getenv("CONFIG_FILE"); configPath != "" {
+		c.ConfigPath = configPath
+	}
 }
 
-func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting Ansible GitOps Service...")
-	log.Printf("Current working directory: %s\n", getCurrentDir())
+// webhookEnabled reports whether the service should run in push-triggered
+// mode instead of polling each watched repository on a timer.
+func (c *Config) webhookEnabled() bool {
+	return c.WebhookSecret != ""
+}
 
+func main() {
 	// Define command line flags
 	playbookPath := flag.String("playbook", "", "Path to the Ansible playbook")
 	inventoryPath := flag.String("inventory", "", "Path to the Ansible inventory")
@@ -88,9 +128,24 @@ func main() {
 	branch := flag.String("branch", "main", "Branch to watch for changes")
 	githubToken := flag.String("github-token", "", "GitHub Personal Access Token")
 	githubUser := flag.String("github-user", "", "GitHub username")
+	webhookListen := flag.String("webhook-listen", ":8080", "Address for the embedded HTTP server exposing /webhook/github and /metrics")
+	webhookSecret := flag.String("webhook-secret", "", "Shared secret used to validate GitHub webhook signatures; enables push-triggered mode")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn, or error")
+	sshKeyPath := flag.String("ssh-key", "", "Path to an SSH private key to use instead of the GitHub token")
+	pollMode := flag.String("poll-mode", "git", "How to detect changes when webhooks are disabled: \"git\" (go-git fetch) or \"api\" (GitHub REST API with ETags)")
+	stateFilePath := flag.String("state-file", "gitops-state.json", "Path to the on-disk state file used by --poll-mode=api to persist ETags/SHAs across restarts")
+	shutdownGrace := flag.Duration("shutdown-grace", 30*time.Second, "How long to wait for in-flight commands to exit after SIGINT before sending SIGKILL")
+	configPath := flag.String("config", "", "Path to a YAML config for multi-playbook routing rules; --playbook/--inventory remain the fallback for repos it doesn't cover")
 
 	flag.Parse()
 
+	level := *logLevel
+	if env := os.Getenv("LOG_LEVEL"); env != "" {
+		level = env
+	}
+	slog.SetDefault(newLogger(level))
+	slog.Info("Starting Ansible GitOps Service...", "cwd", getCurrentDir())
+
 	// Create config from flags
 	config := Config{
 		PlaybookPath:  *playbookPath,
@@ -99,6 +154,13 @@ func main() {
 		Branch:        *branch,
 		GitHubToken:   *githubToken,
 		GitHubUser:    *githubUser,
+		WebhookListen: *webhookListen,
+		WebhookSecret: *webhookSecret,
+		SSHKeyPath:    *sshKeyPath,
+		PollMode:      *pollMode,
+		StateFilePath: *stateFilePath,
+		ShutdownGrace: *shutdownGrace,
+		ConfigPath:    *configPath,
 	}
 
 	// Parse watch repos
@@ -109,27 +171,39 @@ func main() {
 	// Load configuration from environment variables
 	config.LoadFromEnv()
 
-	log.Printf("Configuration:\n")
-	log.Printf("  Playbook Path: %s\n", config.PlaybookPath)
-	log.Printf("  Inventory Path: %s\n", config.InventoryPath)
-	log.Printf("  Watch Interval: %d seconds\n", config.WatchInterval)
-	log.Printf("  Watch Repositories: %v\n", config.WatchRepos)
-	log.Printf("  Watch Branch: %s\n", config.Branch)
-	log.Printf("  GitHub User: %s\n", config.GitHubUser)
+	slog.Info("Configuration loaded",
+		"playbook", config.PlaybookPath,
+		"inventory", config.InventoryPath,
+		"watch_interval_seconds", config.WatchInterval,
+		"repos", config.WatchRepos,
+		"branch", config.Branch,
+		"github_user", config.GitHubUser,
+		"webhook_enabled", config.webhookEnabled(),
+		"listen", config.WebhookListen,
+		"poll_mode", config.PollMode,
+	)
 
 	// Validate configuration
 	if err := config.Validate(); err != nil {
-		log.Fatalf("Configuration error: %v", err)
+		slog.Error("Configuration error", "error", err)
+		os.Exit(1)
 	}
 
 	// Check if Ansible is available
 	if err := checkAnsibleAvailability(); err != nil {
-		log.Fatalf("Ansible not available: %v", err)
+		slog.Error("Ansible not available", "error", err)
+		os.Exit(1)
 	}
 
-	// Configure Git with GitHub credentials
-	if err := configureGit(config); err != nil {
-		log.Fatalf("Git configuration error: %v", err)
+	var routing *RoutingConfig
+	if config.ConfigPath != "" {
+		loaded, err := loadRoutingConfig(config.ConfigPath)
+		if err != nil {
+			slog.Error("Invalid routing config", "path", config.ConfigPath, "error", err)
+			os.Exit(1)
+		}
+		routing = loaded
+		slog.Info("Loaded multi-playbook routing config", "path", config.ConfigPath, "repos", len(routing.Repos))
 	}
 
 	// Create context with cancellation
@@ -140,15 +214,52 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// Start watching for changes in a goroutine
-	go watchForChanges(ctx, config)
+	var wg sync.WaitGroup
+
+	queue := newWorkQueue(ctx, config, routing)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		queue.run()
+	}()
+
+	server := newServer(config, queue)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		slog.Info("Embedded HTTP server listening", "addr", config.WebhookListen, "webhook_enabled", config.webhookEnabled())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server error", "error", err)
+		}
+	}()
+
+	if !config.webhookEnabled() {
+		// No webhook secret configured, fall back to polling.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if config.PollMode == "api" {
+				watchForChangesViaAPI(ctx, config, queue)
+			} else {
+				watchForChanges(ctx, config, queue)
+			}
+		}()
+	}
 
 	// Wait for shutdown signal
 	<-sigChan
-	log.Println("Received shutdown signal, cleaning up...")
+	slog.Info("Received shutdown signal, cleaning up...")
 	cancel()
-	time.Sleep(time.Second) // Give time for cleanup
-	log.Println("Service stopped")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), config.ShutdownGrace+5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP server did not shut down cleanly", "error", err)
+	}
+
+	logLiveCommands()
+	wg.Wait()
+	slog.Info("Service stopped", "jobs_drained", queue.processedCount())
 }
 
 func checkAnsibleAvailability() error {
@@ -160,7 +271,7 @@ func checkAnsibleAvailability() error {
 	}
 
 	version := strings.TrimSpace(string(output))
-	log.Printf("Ansible version: %s", version)
+	slog.Debug("Ansible version", "version", version)
 
 	// Check if ansible-galaxy is available
 	cmd = exec.Command("ansible-galaxy", "--version")
@@ -169,43 +280,34 @@ func checkAnsibleAvailability() error {
 		return fmt.Errorf("ansible-galaxy not found: %v", err)
 	}
 
-	log.Printf("Ansible Galaxy version: %s", strings.TrimSpace(string(output)))
+	slog.Debug("Ansible Galaxy version", "version", strings.TrimSpace(string(output)))
 	return nil
 }
 
-func configureGit(config Config) error {
-	// Configure Git to use the token for authentication
-	commands := []struct {
-		cmd  string
-		args []string
-	}{
-		{"git", []string{"config", "--global", "credential.helper", "store"}},
-		{"git", []string{"config", "--global", "user.name", config.GitHubUser}},
-		{"git", []string{"config", "--global", "url.https://" + config.GitHubToken + "@github.com/.insteadOf", "https://github.com/"}},
-	}
-
-	for _, cmd := range commands {
-		execCmd := exec.Command(cmd.cmd, cmd.args...)
-		if err := execCmd.Run(); err != nil {
-			return fmt.Errorf("error running git config: %v", err)
-		}
+// gitAuth builds the go-git auth method for config: an SSH key when
+// configured, otherwise HTTP basic auth using the GitHub PAT. Neither
+// credential is ever written to disk.
+func gitAuth(config Config) gitops.Auth {
+	return gitops.Auth{
+		Username:   config.GitHubUser,
+		Password:   config.GitHubToken,
+		SSHKeyPath: config.SSHKeyPath,
 	}
-
-	return nil
 }
 
-func watchForChanges(ctx context.Context, config Config) {
+func watchForChanges(ctx context.Context, config Config, queue *workQueue) {
 	repoStates := make(map[string]string)
 
 	// Get initial states
 	for _, repo := range config.WatchRepos {
-		hash, err := getGitHash(repo, config.Branch)
+		hash, err := gitops.Fetch(ctx, repo, config.Branch, gitAuth(config))
 		if err != nil {
-			log.Printf("Error getting initial hash for %s: %v", repo, err)
+			slog.Error("Error getting initial hash", "repo", repo, "error", err)
 			continue
 		}
-		repoStates[repo] = hash
-		log.Printf("Initial git hash for %s (branch: %s): %s", repo, config.Branch, hash)
+		repoStates[repo] = hash.String()
+		recordRepoHash(repo, "", hash.String())
+		slog.Info("Initial git hash", "repo", repo, "branch", config.Branch, "hash", hash.String())
 	}
 
 	ticker := time.NewTicker(time.Duration(config.WatchInterval) * time.Second)
@@ -214,107 +316,235 @@ func watchForChanges(ctx context.Context, config Config) {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Stopping watch loop")
+			slog.Info("Stopping watch loop")
 			return
 		case <-ticker.C:
-			log.Printf("=== Checking repositories for changes (interval: %d seconds) ===", config.WatchInterval)
+			slog.Debug("Checking repositories for changes", "interval_seconds", config.WatchInterval)
 
 			if err := checkAnsibleAvailability(); err != nil {
-				log.Printf("Warning: Ansible check failed: %v", err)
+				slog.Warn("Ansible check failed", "error", err)
 				continue
 			}
 
 			changesDetected := false
+			var changedRepos []string
 
 			for repo, lastHash := range repoStates {
 				select {
 				case <-ctx.Done():
 					return
 				default:
-					log.Printf("Checking repository: %s (branch: %s)", repo, config.Branch)
-
-					// Pull latest changes before checking hash
-					if err := pullLatestChanges(repo, config.Branch); err != nil {
-						log.Printf("Error pulling changes for %s: %v", repo, err)
-						continue
-					}
+					slog.Debug("Checking repository", "repo", repo, "branch", config.Branch)
 
-					currentHash, err := getGitHash(repo, config.Branch)
+					pollStart := time.Now()
+					currentHash, err := gitops.Fetch(ctx, repo, config.Branch, gitAuth(config))
+					metrics.GitPollDuration.WithLabelValues(repo).Observe(time.Since(pollStart).Seconds())
 					if err != nil {
-						log.Printf("Error checking %s: %v", repo, err)
+						metrics.GitPollErrors.WithLabelValues(repo).Inc()
+						slog.Error("Error polling repository", "repo", repo, "error", err)
 						continue
 					}
 
-					if currentHash != lastHash {
-						log.Printf("🔔 Detected changes in repository %s (branch: %s)", repo, config.Branch)
-						log.Printf("   Old hash: %s", lastHash)
-						log.Printf("   New hash: %s", currentHash)
+					if currentHash.String() != lastHash {
+						slog.Info("Detected changes in repository",
+							"repo", repo, "branch", config.Branch,
+							"old_hash", lastHash, "new_hash", currentHash.String(),
+							"duration_ms", time.Since(pollStart).Milliseconds(),
+						)
 
 						changesDetected = true
-						repoStates[repo] = currentHash
+						changedRepos = append(changedRepos, repo)
+						recordRepoHash(repo, lastHash, currentHash.String())
+						repoStates[repo] = currentHash.String()
 					} else {
-						log.Printf("✓ No changes detected in %s", repo)
+						slog.Debug("No changes detected", "repo", repo)
 					}
 				}
 			}
 
 			if changesDetected {
-				log.Println("=== Changes detected, running playbook ===")
-				if err := runPlaybook(config); err != nil {
-					log.Printf("Error running playbook: %v", err)
-				} else {
-					log.Println("✅ Playbook executed successfully")
+				slog.Info("Changes detected, queuing playbook run", "repos", changedRepos)
+				for _, repo := range changedRepos {
+					queue.enqueue(repo)
 				}
 			} else {
-				log.Println("=== No changes detected in any repository ===")
+				slog.Debug("No changes detected in any repository")
+			}
+		}
+	}
+}
+
+// workQueue serializes playbook runs so that a burst of webhook deliveries
+// or a webhook arriving mid-poll can't run Ansible against the same repo
+// concurrently. Both the polling loop and the webhook receiver feed jobs
+// into the same queue.
+type workQueue struct {
+	ctx       context.Context
+	config    Config
+	routing   *RoutingConfig
+	jobs      chan string
+	processed int64
+}
+
+func newWorkQueue(ctx context.Context, config Config, routing *RoutingConfig) *workQueue {
+	return &workQueue{
+		ctx:     ctx,
+		config:  config,
+		routing: routing,
+		jobs:    make(chan string, 32),
+	}
+}
+
+// processedCount reports how many jobs run() has finished pulling off the
+// queue and syncing (successfully or not), so shutdown can log what was
+// actually drained instead of a static count of supervisor goroutines.
+func (q *workQueue) processedCount() int64 {
+	return atomic.LoadInt64(&q.processed)
+}
+
+// enqueue schedules repo to be synced and, if that sync finds changes, to
+// have the playbook run against it. It never blocks the caller beyond a
+// full queue or shutdown.
+func (q *workQueue) enqueue(repo string) {
+	select {
+	case q.jobs <- repo:
+	case <-q.ctx.Done():
+	default:
+		slog.Warn("Work queue full, dropping job", "repo", repo)
+	}
+}
+
+func (q *workQueue) run() {
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case repo := <-q.jobs:
+			if err := syncRepo(q.ctx, q.config, q.routing, repo); err != nil {
+				slog.Error("Error syncing repository", "repo", repo, "error", err)
 			}
+			atomic.AddInt64(&q.processed, 1)
 		}
 	}
 }
 
-func getGitHash(repoPath, branch string) (string, error) {
-	// First ensure we're on the correct branch
-	checkoutCmd := exec.Command("git", "-C", repoPath, "checkout", branch)
-	if err := checkoutCmd.Run(); err != nil {
-		return "", fmt.Errorf("error checking out branch %s: %v", branch, err)
+// syncRepo is the single code path that pulls the latest commit for repo
+// and runs the playbook against it. Both the polling loop (on a detected
+// hash change) and the webhook receiver (on a matching push event) funnel
+// through here via workQueue, so behavior is identical regardless of how
+// the sync was triggered.
+func syncRepo(ctx context.Context, config Config, routing *RoutingConfig, repo string) error {
+	oldHash, _ := gitops.HeadHash(repo) // best-effort; zero hash on a repo's first sync
+
+	newHash, err := gitops.Pull(ctx, repo, config.Branch, gitAuth(config))
+	if err != nil {
+		return fmt.Errorf("error pulling changes for %s: %v", repo, err)
+	}
+	recordRepoHash(repo, oldHash.String(), newHash.String())
+
+	if routing == nil {
+		slog.Info("Running playbook", "repo", repo, "playbook", config.PlaybookPath)
+		return runAndRecordPlaybook(repo, func() error { return runPlaybook(ctx, config) })
 	}
 
-	// Then get the hash
-	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD")
-	output, err := cmd.Output()
+	repoRouting, ok := routing.find(repo)
+	if !ok {
+		slog.Info("No routing entry for repo, falling back to --playbook", "repo", repo, "playbook", config.PlaybookPath)
+		return runAndRecordPlaybook(repo, func() error { return runPlaybook(ctx, config) })
+	}
+
+	changedFiles, err := gitops.ChangedFiles(repo, oldHash, newHash)
 	if err != nil {
-		return "", fmt.Errorf("error getting git hash: %v", err)
+		return fmt.Errorf("diffing changed files for %s: %w", repo, err)
+	}
+
+	rules := repoRouting.matchingRules(changedFiles)
+	if len(rules) == 0 {
+		slog.Info("No playbook rules matched changed files", "repo", repo, "changed_files", len(changedFiles))
+		return nil
+	}
+
+	for _, rule := range rules {
+		rule := rule
+		slog.Info("Running playbook", "repo", repo, "playbook", rule.Playbook, "tags", rule.Tags, "limit", rule.Limit)
+		if err := runAndRecordPlaybook(repo, func() error { return runPlaybookRule(ctx, config, rule) }); err != nil {
+			return err
+		}
 	}
-	return strings.TrimSpace(string(output)), nil
+
+	return nil
 }
 
-func pullLatestChanges(repoPath, branch string) error {
-	// Ensure we're on the correct branch
-	checkoutCmd := exec.Command("git", "-C", repoPath, "checkout", branch)
-	if err := checkoutCmd.Run(); err != nil {
-		return fmt.Errorf("error checking out branch %s: %v", branch, err)
+// runAndRecordPlaybook runs run, which is expected to invoke
+// ansible-playbook, and wraps it with the timing/metrics/logging every
+// playbook run needs regardless of which rule (or the lack of one)
+// selected it.
+func runAndRecordPlaybook(repo string, run func() error) error {
+	start := time.Now()
+	err := run()
+	duration := time.Since(start)
+	metrics.PlaybookDuration.WithLabelValues(repo).Observe(duration.Seconds())
+
+	if err != nil {
+		metrics.PlaybookRuns.WithLabelValues(repo, "failure").Inc()
+		return fmt.Errorf("error running playbook for %s: %v", repo, err)
 	}
 
-	// Pull changes
-	cmd := exec.Command("git", "-C", repoPath, "pull", "origin", branch)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	metrics.PlaybookRuns.WithLabelValues(repo, "success").Inc()
+	metrics.LastSuccessTime.WithLabelValues(repo).SetToCurrentTime()
+	slog.Info("Playbook executed successfully", "repo", repo, "duration_ms", duration.Milliseconds())
+	return nil
 }
 
-func runPlaybook(config Config) error {
+func runPlaybook(ctx context.Context, config Config) error {
 	cmd := exec.Command("ansible-playbook", config.PlaybookPath)
 
 	if config.InventoryPath != "" {
 		cmd.Args = append(cmd.Args, "-i", config.InventoryPath)
 	}
 
-	log.Printf("Executing command: %v", cmd.Args)
+	slog.Debug("Executing command", "args", cmd.Args)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return runCommandWithTimeout(ctx, cmd, 30*time.Minute, config.ShutdownGrace)
+}
+
+// runPlaybookRule runs ansible-playbook for a single routing rule,
+// threading its inventory, extra vars, tags, and limit through to the CLI
+// the same way runPlaybook does for the single-playbook fallback.
+func runPlaybookRule(ctx context.Context, config Config, rule PlaybookRule) error {
+	cmd := exec.Command("ansible-playbook", rule.Playbook)
+
+	inventory := rule.Inventory
+	if inventory == "" {
+		inventory = config.InventoryPath
+	}
+	if inventory != "" {
+		cmd.Args = append(cmd.Args, "-i", inventory)
+	}
+
+	for key, value := range rule.ExtraVars {
+		formatted, err := formatExtraVar(value)
+		if err != nil {
+			return fmt.Errorf("formatting extra_vars.%s for %s: %w", key, rule.Playbook, err)
+		}
+		cmd.Args = append(cmd.Args, "-e", fmt.Sprintf("%s=%s", key, formatted))
+	}
+	if len(rule.Tags) > 0 {
+		cmd.Args = append(cmd.Args, "--tags", strings.Join(rule.Tags, ","))
+	}
+	if rule.Limit != "" {
+		cmd.Args = append(cmd.Args, "--limit", rule.Limit)
+	}
+
+	slog.Debug("Executing command", "args", cmd.Args)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	return runCommandWithTimeout(cmd, 30*time.Minute)
+	return runCommandWithTimeout(ctx, cmd, 30*time.Minute, config.ShutdownGrace)
 }
 
 func getCurrentDir() string {
@@ -330,8 +560,13 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func runCommandWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// runCommandWithTimeout runs cmd to completion, killing it if it exceeds
+// timeout. If shutdownCtx is canceled first (a SIGTERM to this process), it
+// sends SIGINT instead of killing outright, giving the child (e.g. Ansible)
+// a chance to run its own cleanup handlers, then escalates to SIGKILL after
+// shutdownGrace.
+func runCommandWithTimeout(shutdownCtx context.Context, cmd *exec.Cmd, timeout, shutdownGrace time.Duration) error {
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd.WaitDelay = timeout
@@ -339,6 +574,9 @@ func runCommandWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
 		return fmt.Errorf("failed to start command: %v", err)
 	}
 
+	liveCommands.add(cmd)
+	defer liveCommands.remove(cmd)
+
 	done := make(chan error, 1)
 	go func() {
 		done <- cmd.Wait()
@@ -347,10 +585,31 @@ func runCommandWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
 	select {
 	case err := <-done:
 		return err
-	case <-ctx.Done():
+	case <-timeoutCtx.Done():
+		slog.Warn("Command exceeded timeout, killing", "pid", cmd.Process.Pid)
 		if err := cmd.Process.Kill(); err != nil {
 			return fmt.Errorf("failed to kill process: %v", err)
 		}
 		return fmt.Errorf("command timed out")
+	case <-shutdownCtx.Done():
+		slog.Info("Shutting down, sending SIGINT to command", "pid", cmd.Process.Pid)
+		if err := cmd.Process.Signal(syscall.SIGINT); err != nil {
+			slog.Warn("Failed to send SIGINT, killing instead", "pid", cmd.Process.Pid, "error", err)
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("command interrupted by shutdown")
+		}
+
+		graceTimer := time.NewTimer(shutdownGrace)
+		defer graceTimer.Stop()
+		select {
+		case err := <-done:
+			return err
+		case <-graceTimer.C:
+			slog.Warn("Shutdown grace period elapsed, sending SIGKILL", "pid", cmd.Process.Pid)
+			if err := cmd.Process.Kill(); err != nil {
+				return fmt.Errorf("failed to kill process after grace period: %v", err)
+			}
+			return fmt.Errorf("command killed after shutdown grace period")
+		}
 	}
 }
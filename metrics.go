@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metrics holds the Prometheus collectors emitted by this service. All of
+// them are registered against the default registry so promhttp.Handler()
+// picks them up without any extra wiring at the call site.
+var metrics = struct {
+	PlaybookRuns     *prometheus.CounterVec
+	GitPollErrors    *prometheus.CounterVec
+	PlaybookDuration *prometheus.HistogramVec
+	GitPollDuration  *prometheus.HistogramVec
+	LastSuccessTime  *prometheus.GaugeVec
+	RepoCurrentHash  *prometheus.GaugeVec
+}{
+	PlaybookRuns: promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gitops_playbook_runs_total",
+		Help: "Total number of ansible-playbook runs, labeled by repo and outcome.",
+	}, []string{"repo", "status"}),
+
+	GitPollErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gitops_git_poll_errors_total",
+		Help: "Total number of errors encountered while polling a repo for changes.",
+	}, []string{"repo"}),
+
+	PlaybookDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "gitops_playbook_duration_seconds",
+		Help: "Duration of ansible-playbook runs in seconds.",
+	}, []string{"repo"}),
+
+	GitPollDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "gitops_git_poll_duration_seconds",
+		Help: "Duration of git poll (pull + hash check) operations in seconds.",
+	}, []string{"repo"}),
+
+	LastSuccessTime: promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitops_last_success_timestamp",
+		Help: "Unix timestamp of the last successful playbook run for a repo.",
+	}, []string{"repo"}),
+
+	RepoCurrentHash: promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gitops_repo_current_hash",
+		Help: "Always 1; the currently known commit hash is carried on the hash label.",
+	}, []string{"repo", "hash"}),
+}
+
+// recordRepoHash replaces the previously reported hash gauge for repo so
+// only the current commit's time series stays at 1.
+func recordRepoHash(repo, oldHash, newHash string) {
+	if oldHash != "" {
+		metrics.RepoCurrentHash.DeleteLabelValues(repo, oldHash)
+	}
+	metrics.RepoCurrentHash.WithLabelValues(repo, newHash).Set(1)
+}
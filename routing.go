@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// RoutingConfig is the schema behind --config: which playbook(s) to run
+// for a repo, chosen by which paths changed instead of always running the
+// one playbook passed via --playbook.
+type RoutingConfig struct {
+	Repos []RepoRouting `yaml:"repos"`
+}
+
+// RepoRouting holds the routing rules for a single watched repo. Name is
+// matched against the repo's "owner/repo" full name, the same way the
+// webhook receiver matches incoming push events.
+type RepoRouting struct {
+	Name  string         `yaml:"name"`
+	Rules []PlaybookRule `yaml:"rules"`
+}
+
+// PlaybookRule runs Playbook when any changed file matches one of Paths
+// (doublestar glob syntax, so "roles/web/**" works as expected).
+type PlaybookRule struct {
+	Paths     []string               `yaml:"paths"`
+	Playbook  string                 `yaml:"playbook"`
+	Inventory string                 `yaml:"inventory,omitempty"`
+	ExtraVars map[string]interface{} `yaml:"extra_vars,omitempty"`
+	Tags      []string               `yaml:"tags,omitempty"`
+	Limit     string                 `yaml:"limit,omitempty"`
+}
+
+// loadRoutingConfig reads and validates a --config YAML file, returning an
+// error that points at the offending key rather than a raw parse failure.
+func loadRoutingConfig(path string) (*RoutingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var cfg RoutingConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *RoutingConfig) validate() error {
+	if len(c.Repos) == 0 {
+		return fmt.Errorf("config: at least one entry under \"repos\" is required")
+	}
+
+	for i, repo := range c.Repos {
+		if repo.Name == "" {
+			return fmt.Errorf("config: repos[%d].name is required", i)
+		}
+		if len(repo.Rules) == 0 {
+			return fmt.Errorf("config: repos[%d] (%s): at least one rule is required", i, repo.Name)
+		}
+		for j, rule := range repo.Rules {
+			if len(rule.Paths) == 0 {
+				return fmt.Errorf("config: repos[%d].rules[%d] (%s): \"paths\" must have at least one pattern", i, j, repo.Name)
+			}
+			if rule.Playbook == "" {
+				return fmt.Errorf("config: repos[%d].rules[%d] (%s): \"playbook\" is required", i, j, repo.Name)
+			}
+			for _, pattern := range rule.Paths {
+				if _, err := doublestar.Match(pattern, "x"); err != nil {
+					return fmt.Errorf("config: repos[%d].rules[%d] (%s): invalid path pattern %q: %w", i, j, repo.Name, pattern, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// find returns the routing rules for repo, matched by "owner/repo" full
+// name regardless of whether repo is a local path, bare name, or URL.
+func (c *RoutingConfig) find(repo string) (RepoRouting, bool) {
+	full := repoFullName(repo)
+	for _, r := range c.Repos {
+		if strings.EqualFold(r.Name, full) {
+			return r, true
+		}
+	}
+	return RepoRouting{}, false
+}
+
+// matchingRules returns every rule whose paths glob-match at least one of
+// changedFiles.
+func (r RepoRouting) matchingRules(changedFiles []string) []PlaybookRule {
+	var matched []PlaybookRule
+	for _, rule := range r.Rules {
+		for _, file := range changedFiles {
+			if rule.matches(file) {
+				matched = append(matched, rule)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func (rule PlaybookRule) matches(file string) bool {
+	for _, pattern := range rule.Paths {
+		if ok, _ := doublestar.Match(pattern, file); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatExtraVar renders an extra_vars value for ansible-playbook's -e
+// flag. Scalars (strings, numbers, bools) are passed through as-is; maps
+// and lists are JSON-encoded, which ansible-playbook -e accepts natively.
+func formatExtraVar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
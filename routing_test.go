@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRoutingConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("writing fixture config: %v", err)
+	}
+	return path
+}
+
+func TestLoadRoutingConfigValid(t *testing.T) {
+	path := writeRoutingConfig(t, `
+repos:
+  - name: acme/widgets
+    rules:
+      - paths: ["roles/web/**"]
+        playbook: playbooks/web.yml
+        tags: ["web"]
+        extra_vars:
+          replicas: 3
+          enabled: true
+          tiers: ["a", "b"]
+`)
+
+	cfg, err := loadRoutingConfig(path)
+	if err != nil {
+		t.Fatalf("loadRoutingConfig() error = %v", err)
+	}
+	if len(cfg.Repos) != 1 || len(cfg.Repos[0].Rules) != 1 {
+		t.Fatalf("unexpected config shape: %+v", cfg)
+	}
+}
+
+func TestLoadRoutingConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"no repos", `repos: []`},
+		{"missing repo name", `
+repos:
+  - rules:
+      - paths: ["**"]
+        playbook: playbooks/web.yml
+`},
+		{"no rules", `
+repos:
+  - name: acme/widgets
+    rules: []
+`},
+		{"missing paths", `
+repos:
+  - name: acme/widgets
+    rules:
+      - playbook: playbooks/web.yml
+`},
+		{"missing playbook", `
+repos:
+  - name: acme/widgets
+    rules:
+      - paths: ["**"]
+`},
+		{"invalid glob pattern", `
+repos:
+  - name: acme/widgets
+    rules:
+      - paths: ["roles/["]
+        playbook: playbooks/web.yml
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeRoutingConfig(t, tt.yaml)
+			if _, err := loadRoutingConfig(path); err == nil {
+				t.Errorf("loadRoutingConfig() expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestRepoRoutingMatchingRules(t *testing.T) {
+	routing := RepoRouting{
+		Name: "acme/widgets",
+		Rules: []PlaybookRule{
+			{Paths: []string{"roles/web/**"}, Playbook: "web.yml"},
+			{Paths: []string{"roles/db/**", "migrations/*.sql"}, Playbook: "db.yml"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		changed []string
+		want    []string
+	}{
+		{"matches one rule", []string{"roles/web/tasks/main.yml"}, []string{"web.yml"}},
+		{"matches multiple rules", []string{"roles/web/tasks/main.yml", "migrations/001.sql"}, []string{"web.yml", "db.yml"}},
+		{"matches no rule", []string{"README.md"}, nil},
+		{"second pattern of a rule matches", []string{"migrations/002.sql"}, []string{"db.yml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := routing.matchingRules(tt.changed)
+			if len(matched) != len(tt.want) {
+				t.Fatalf("matchingRules() = %d rules, want %d", len(matched), len(tt.want))
+			}
+			for i, rule := range matched {
+				if rule.Playbook != tt.want[i] {
+					t.Errorf("matchingRules()[%d].Playbook = %q, want %q", i, rule.Playbook, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatExtraVar(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"string", "prod", "prod"},
+		{"int", 3, "3"},
+		{"bool", true, "true"},
+		{"list", []interface{}{"a", "b"}, `["a","b"]`},
+		{"map", map[string]interface{}{"key": "value"}, `{"key":"value"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatExtraVar(tt.value)
+			if err != nil {
+				t.Fatalf("formatExtraVar() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatExtraVar(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
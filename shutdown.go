@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log/slog"
+	"os/exec"
+	"sync"
+)
+
+// cmdRegistry tracks every *exec.Cmd this process currently has running, so
+// shutdown can report how many (and, if ever needed, inspect them) instead
+// of blindly sleeping for a fixed duration.
+type cmdRegistry struct {
+	mu   sync.Mutex
+	cmds map[*exec.Cmd]struct{}
+}
+
+var liveCommands = &cmdRegistry{cmds: make(map[*exec.Cmd]struct{})}
+
+func (r *cmdRegistry) add(cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cmds[cmd] = struct{}{}
+}
+
+func (r *cmdRegistry) remove(cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cmds, cmd)
+}
+
+func (r *cmdRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cmds)
+}
+
+// logLiveCommands is a small shutdown-time helper so operators can see what
+// the drain is waiting on.
+func logLiveCommands() {
+	if n := liveCommands.len(); n > 0 {
+		slog.Info("Waiting for in-flight commands to exit", "count", n)
+	}
+}
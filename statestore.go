@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// repoAPIState is what we remember about a single repo between polls in
+// --poll-mode=api, so a restart doesn't re-trigger a playbook run for a
+// repo whose SHA hasn't moved since the last check.
+type repoAPIState struct {
+	ETag      string    `json:"etag"`
+	LastSHA   string    `json:"last_sha"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+// stateStore is a small JSON-backed, mutex-protected map of repo to
+// repoAPIState. It's intentionally simple: load once at startup, save
+// after every update.
+type stateStore struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]repoAPIState
+}
+
+func loadStateStore(path string) (*stateStore, error) {
+	store := &stateStore{path: path, states: make(map[string]repoAPIState)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state file %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &store.states); err != nil {
+		return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+	}
+
+	return store, nil
+}
+
+func (s *stateStore) get(repo string) repoAPIState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[repo]
+}
+
+func (s *stateStore) set(repo string, state repoAPIState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[repo] = state
+
+	data, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling state: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("writing state file %s: %w", s.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateStoreMissingFileStartsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("loadStateStore() error = %v", err)
+	}
+
+	if got := store.get("acme/widgets"); got != (repoAPIState{}) {
+		t.Errorf("get() on empty store = %+v, want zero value", got)
+	}
+}
+
+func TestStateStoreSetPersistsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("loadStateStore() error = %v", err)
+	}
+
+	want := repoAPIState{ETag: `"abc123"`, LastSHA: "deadbeef", LastCheck: time.Now().UTC().Truncate(time.Second)}
+	if err := store.set("acme/widgets", want); err != nil {
+		t.Fatalf("set() error = %v", err)
+	}
+
+	reloaded, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("loadStateStore() after set error = %v", err)
+	}
+
+	got := reloaded.get("acme/widgets")
+	if got.ETag != want.ETag || got.LastSHA != want.LastSHA || !got.LastCheck.Equal(want.LastCheck) {
+		t.Errorf("reloaded state = %+v, want %+v", got, want)
+	}
+}
+
+func TestStateStoreMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	if _, err := loadStateStore(path); err == nil {
+		t.Errorf("expected an error loading a malformed state file")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// githubPushEvent is the subset of a GitHub "push" webhook payload that we
+// need to decide whether a sync is warranted.
+type githubPushEvent struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// newServer builds the embedded *http.Server that always exposes /metrics
+// and, when a webhook secret is configured, /webhook/github. Valid push
+// events for a watched repo/branch are handed to queue so the actual sync
+// runs through the same code path as the polling loop.
+func newServer(config Config, queue *workQueue) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	if config.webhookEnabled() {
+		mux.HandleFunc("/webhook/github", handleGitHubWebhook(config, queue))
+	}
+
+	return &http.Server{
+		Addr:              config.WebhookListen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+func handleGitHubWebhook(config Config, queue *workQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		if !validSignature(config.WebhookSecret, r.Header.Get("X-Hub-Signature-256"), body) {
+			slog.Warn("Webhook rejected: invalid signature", "remote_addr", r.RemoteAddr)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("X-GitHub-Event") != "push" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var event githubPushEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		repo, ok := matchWatchedRepo(config, event)
+		if !ok {
+			slog.Debug("Webhook ignored: repo/branch not watched", "repo", event.Repository.FullName, "ref", event.Ref)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		slog.Info("Webhook push received, queuing sync", "repo", repo)
+		queue.enqueue(repo)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// validSignature verifies the X-Hub-Signature-256 header against an
+// HMAC-SHA256 of body keyed by secret, comparing in constant time.
+func validSignature(secret, header string, body []byte) bool {
+	if secret == "" || header == "" {
+		return false
+	}
+
+	const prefix = "sha256="
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+
+	expected := hmac.New(sha256.New, []byte(secret))
+	expected.Write(body)
+	want := expected.Sum(nil)
+
+	got, err := hex.DecodeString(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(got, want)
+}
+
+// matchWatchedRepo finds the configured WatchRepos entry matching the
+// pushed repository and branch, if any.
+func matchWatchedRepo(config Config, event githubPushEvent) (string, bool) {
+	wantRef := fmt.Sprintf("refs/heads/%s", config.Branch)
+	if event.Ref != wantRef {
+		return "", false
+	}
+
+	for _, repo := range config.WatchRepos {
+		if strings.EqualFold(repoFullName(repo), event.Repository.FullName) {
+			return repo, true
+		}
+	}
+
+	return "", false
+}
+
+// repoFullName extracts the "owner/repo" form from a configured watch
+// entry, which may be a bare full name, a local path, or a clone URL.
+func repoFullName(repo string) string {
+	trimmed := strings.TrimSuffix(repo, ".git")
+	parts := strings.Split(strings.Trim(trimmed, "/"), "/")
+	if len(parts) < 2 {
+		return trimmed
+	}
+	return strings.Join(parts[len(parts)-2:], "/")
+}
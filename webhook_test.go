@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		want   bool
+	}{
+		{"valid signature", "s3cr3t", sign("s3cr3t", body), true},
+		{"wrong secret", "s3cr3t", sign("other", body), false},
+		{"tampered body", "s3cr3t", sign("s3cr3t", []byte(`{"ref":"refs/heads/evil"}`)), false},
+		{"missing prefix", "s3cr3t", hex.EncodeToString([]byte("deadbeef")), false},
+		{"empty header", "s3cr3t", "", false},
+		{"empty secret", "", sign("s3cr3t", body), false},
+		{"non-hex signature", "s3cr3t", "sha256=not-hex", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSignature(tt.secret, tt.header, body); got != tt.want {
+				t.Errorf("validSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchWatchedRepo(t *testing.T) {
+	config := Config{
+		Branch:     "main",
+		WatchRepos: []string{"/srv/repos/acme/widgets", "https://github.com/acme/other.git"},
+	}
+
+	t.Run("matches by full name and branch", func(t *testing.T) {
+		event := githubPushEvent{Ref: "refs/heads/main"}
+		event.Repository.FullName = "acme/widgets"
+
+		repo, ok := matchWatchedRepo(config, event)
+		if !ok {
+			t.Fatalf("expected a match for acme/widgets")
+		}
+		if repo != "/srv/repos/acme/widgets" {
+			t.Errorf("repo = %q, want the configured watch entry", repo)
+		}
+	})
+
+	t.Run("matches URL-based watch entry", func(t *testing.T) {
+		event := githubPushEvent{Ref: "refs/heads/main"}
+		event.Repository.FullName = "acme/other"
+
+		repo, ok := matchWatchedRepo(config, event)
+		if !ok {
+			t.Fatalf("expected a match for acme/other")
+		}
+		if repo != "https://github.com/acme/other.git" {
+			t.Errorf("repo = %q, want the configured watch entry", repo)
+		}
+	})
+
+	t.Run("branch mismatch is ignored", func(t *testing.T) {
+		event := githubPushEvent{Ref: "refs/heads/develop"}
+		event.Repository.FullName = "acme/other"
+
+		if _, ok := matchWatchedRepo(config, event); ok {
+			t.Errorf("expected no match for an unwatched branch")
+		}
+	})
+
+	t.Run("unwatched repo is ignored", func(t *testing.T) {
+		event := githubPushEvent{Ref: "refs/heads/main"}
+		event.Repository.FullName = "someone-else/unrelated"
+
+		if _, ok := matchWatchedRepo(config, event); ok {
+			t.Errorf("expected no match for an unwatched repo")
+		}
+	})
+}
+
+func TestRepoFullName(t *testing.T) {
+	tests := []struct {
+		repo string
+		want string
+	}{
+		{"acme/widgets", "acme/widgets"},
+		{"https://github.com/acme/widgets.git", "acme/widgets"},
+		{"/srv/repos/acme/widgets/", "acme/widgets"},
+		{"widgets", "widgets"},
+	}
+
+	for _, tt := range tests {
+		if got := repoFullName(tt.repo); got != tt.want {
+			t.Errorf("repoFullName(%q) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+}